@@ -0,0 +1,113 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// defaultHeaderTemplate renders the copyright line from an inferred
+// authorship: one or more holders and the year range they authored in.
+// Users can point -template at a file with their own layout.
+const defaultHeaderTemplate = `Copyright {{.Year}} {{.Holders | join ", "}}`
+
+// headerFuncs are the functions available to a header template. join takes
+// its separator before the piped slice (e.g. `.Holders | join ", "`), so it
+// can't be strings.Join directly: a template pipe appends the piped value
+// as the final argument, and strings.Join wants the slice first.
+var headerFuncs = template.FuncMap{
+	"join": func(sep string, elems []string) string { return strings.Join(elems, sep) },
+}
+
+// headerData is the value a header template is executed against.
+type headerData struct {
+	Year    string
+	Holders []string
+}
+
+// authorship is a file's inferred copyright holders and the year range of
+// their contributions, per `git log --follow`.
+type authorship struct {
+	holders          []string
+	yearFrom, yearTo int
+}
+
+// inferAuthorship derives path's copyright holders from its git history.
+// Author names are deduplicated as git itself would: if a .mailmap file
+// exists at the root of dir's repository, git already canonicalizes
+// `%aN` through it, so no separate mailmap parsing is needed here.
+func inferAuthorship(dir, path string) (authorship, error) {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return authorship{}, err
+	}
+
+	cmd := exec.Command("git", "log", "--follow", "--format=%aN\t%ad", "--date=format:%Y", "--", rel)
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return authorship{}, fmt.Errorf("git log %q: %w", path, err)
+	}
+
+	var a authorship
+	seen := map[string]bool{}
+	sc := bufio.NewScanner(bytes.NewReader(out))
+	for sc.Scan() {
+		fields := strings.SplitN(sc.Text(), "\t", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		name, year := fields[0], fields[1]
+		if !seen[name] {
+			seen[name] = true
+			a.holders = append(a.holders, name)
+		}
+		if y, err := strconv.Atoi(year); err == nil {
+			if a.yearFrom == 0 || y < a.yearFrom {
+				a.yearFrom = y
+			}
+			if y > a.yearTo {
+				a.yearTo = y
+			}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return authorship{}, err
+	}
+	if len(a.holders) == 0 {
+		return authorship{}, fmt.Errorf("no git history found for %q", path)
+	}
+	return a, nil
+}
+
+// years renders a's year range as git-log-style "2019-2023", or just
+// "2023" if all contributions landed in a single year.
+func (a authorship) years() string {
+	if a.yearFrom == a.yearTo {
+		return strconv.Itoa(a.yearFrom)
+	}
+	return fmt.Sprintf("%d-%d", a.yearFrom, a.yearTo)
+}
+
+// renderCopyright executes tmplText against a's inferred holders and year
+// range, producing a copyright line such as "Copyright 2019-2023 A, B".
+func renderCopyright(tmplText string, a authorship) (string, error) {
+	tmpl, err := template.New("header").Funcs(headerFuncs).Parse(tmplText)
+	if err != nil {
+		return "", fmt.Errorf("parsing header template: %w", err)
+	}
+
+	var b strings.Builder
+	if err := tmpl.Execute(&b, headerData{Year: a.years(), Holders: a.holders}); err != nil {
+		return "", fmt.Errorf("executing header template: %w", err)
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// runGit runs git with dir as its working directory, failing the test on
+// error.
+func runGit(t *testing.T, dir string, args ...string) {
+	t.Helper()
+	cmd := exec.Command("git", args...)
+	cmd.Dir = dir
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git %v: %v\n%s", args, err, out)
+	}
+}
+
+func TestInferAuthorshipNonDotDir(t *testing.T) {
+	repo := t.TempDir()
+	runGit(t, repo, "init", "-q")
+	runGit(t, repo, "config", "user.name", "Jane Doe")
+	runGit(t, repo, "config", "user.email", "jane@example.com")
+
+	sub := filepath.Join(repo, "sub")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	file := filepath.Join(sub, "main.go")
+	if err := os.WriteFile(file, []byte("package main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	runGit(t, repo, "add", "-A")
+	runGit(t, repo, "commit", "-q", "-m", "initial")
+
+	// crawlFiles(sub) yields paths already prefixed with sub, so this
+	// mirrors how Run actually calls inferAuthorship when linting a
+	// subdirectory rather than ".".
+	a, err := inferAuthorship(sub, file)
+	if err != nil {
+		t.Fatalf("inferAuthorship(%q, %q): %v", sub, file, err)
+	}
+	if len(a.holders) != 1 || a.holders[0] != "Jane Doe" {
+		t.Fatalf("holders = %v, want [Jane Doe]", a.holders)
+	}
+}
+
+func TestRenderCopyrightMultipleHolders(t *testing.T) {
+	a := authorship{holders: []string{"Jane Doe", "John Smith"}, yearFrom: 2019, yearTo: 2023}
+	got, err := renderCopyright(defaultHeaderTemplate, a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Copyright 2019-2023 Jane Doe, John Smith"; got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,241 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// moduleInfo is a resolved dependency: an import path and the version it
+// was resolved to.
+type moduleInfo struct {
+	Path    string
+	Version string
+}
+
+// bomLicense is one license detected for a bomEntry, with the classifier's
+// confidence in the match.
+type bomLicense struct {
+	Type       string  `json:"type"`
+	Confidence float64 `json:"confidence"`
+}
+
+// bomEntry is one dependency in the bill-of-materials, matching the
+// de-facto {project, licenses: [{type, confidence}]} shape consumed by
+// downstream compliance tooling.
+type bomEntry struct {
+	Project  string       `json:"project"`
+	Version  string       `json:"version"`
+	Licenses []bomLicense `json:"licenses"`
+}
+
+// listVendoredModules parses vendor/modules.txt, the format `go mod vendor`
+// writes, for its "# module version" header lines.
+func listVendoredModules(dir string) ([]moduleInfo, error) {
+	f, err := os.Open(filepath.Join(dir, "vendor", "modules.txt"))
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var mods []moduleInfo
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := sc.Text()
+		if !strings.HasPrefix(line, "# ") {
+			continue
+		}
+		fields := strings.Fields(line[len("# "):])
+		if len(fields) < 2 || strings.HasPrefix(fields[1], "=>") {
+			continue
+		}
+		mods = append(mods, moduleInfo{Path: fields[0], Version: fields[1]})
+	}
+	return mods, sc.Err()
+}
+
+// listModulesFromGraph resolves the module graph with `go list -m all`,
+// for repositories that don't vendor their dependencies.
+func listModulesFromGraph(dir string) ([]moduleInfo, error) {
+	cmd := exec.Command("go", "list", "-m", "all")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list -m all: %w", err)
+	}
+
+	var mods []moduleInfo
+	sc := bufio.NewScanner(strings.NewReader(string(out)))
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		// The first line is the main module itself, with no version.
+		if len(fields) < 2 {
+			continue
+		}
+		mods = append(mods, moduleInfo{Path: fields[0], Version: fields[1]})
+	}
+	return mods, sc.Err()
+}
+
+// listModules resolves dir's dependencies, preferring a vendor/ directory
+// when present since it doesn't require network access or a populated
+// module cache.
+func listModules(dir string) ([]moduleInfo, error) {
+	if mods, err := listVendoredModules(dir); err == nil {
+		return mods, nil
+	}
+	return listModulesFromGraph(dir)
+}
+
+// moduleLicenseDir returns the directory a module's source (and therefore
+// its LICENSE file) can be read from: the vendor tree if the module is
+// vendored, otherwise the module cache.
+func moduleLicenseDir(dir string, m moduleInfo) (string, bool) {
+	vendored := filepath.Join(dir, "vendor", m.Path)
+	if fi, err := os.Stat(vendored); err == nil && fi.IsDir() {
+		return vendored, true
+	}
+
+	cmd := exec.Command("go", "env", "GOMODCACHE")
+	cmd.Dir = dir
+	out, err := cmd.Output()
+	if err != nil {
+		return "", false
+	}
+	cacheDir := filepath.Join(strings.TrimSpace(string(out)), fmt.Sprintf("%s@%s", m.Path, m.Version))
+	if fi, err := os.Stat(cacheDir); err == nil && fi.IsDir() {
+		return cacheDir, true
+	}
+	return "", false
+}
+
+// classifyModuleLicense finds and classifies a module's LICENSE file.
+func classifyModuleLicense(dir string, m moduleInfo) (bomLicense, bool) {
+	modDir, ok := moduleLicenseDir(dir, m)
+	if !ok {
+		return bomLicense{}, false
+	}
+	path, err := findLicenseFile(modDir)
+	if err != nil {
+		return bomLicense{}, false
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return bomLicense{}, false
+	}
+	match := classifyLicense(string(b))
+	if match.spdx == "" {
+		return bomLicense{}, false
+	}
+	return bomLicense{Type: match.spdx, Confidence: match.score}, true
+}
+
+// bomOptions configures buildBOM's allow/deny enforcement.
+type bomOptions struct {
+	allow map[string]bool
+	deny  map[string]bool
+}
+
+// buildBOM resolves dir's dependency graph and classifies each dependency's
+// license, returning an error if a dependency's license fails the
+// allow/deny lists.
+func buildBOM(dir string, opts bomOptions) ([]bomEntry, error) {
+	mods, err := listModules(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []bomEntry
+	for _, m := range mods {
+		entry := bomEntry{Project: m.Path, Version: m.Version}
+		lic, ok := classifyModuleLicense(dir, m)
+		if !ok {
+			if len(opts.allow) > 0 {
+				return nil, fmt.Errorf("dependency %q has an unclassifiable license, which is not in the allowlist", m.Path)
+			}
+			entries = append(entries, entry)
+			continue
+		}
+
+		entry.Licenses = append(entry.Licenses, lic)
+
+		if len(opts.deny) > 0 && opts.deny[lic.Type] {
+			return nil, fmt.Errorf("dependency %q has denied license %q", m.Path, lic.Type)
+		}
+		if len(opts.allow) > 0 && !opts.allow[lic.Type] {
+			return nil, fmt.Errorf("dependency %q has license %q, which is not in the allowlist", m.Path, lic.Type)
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// runBOM implements the -bom subcommand: it writes either the native
+// {project, licenses} JSON shape or an SPDX 2.3 document to stdout.
+func runBOM(dir string, opts bomOptions, spdx bool) error {
+	entries, err := buildBOM(dir, opts)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	if spdx {
+		return enc.Encode(spdxDocument(entries))
+	}
+	return enc.Encode(entries)
+}
+
+// spdxPackage is one package entry in an SPDX 2.3 document.
+type spdxPackage struct {
+	SPDXID           string `json:"SPDXID"`
+	Name             string `json:"name"`
+	VersionInfo      string `json:"versionInfo,omitempty"`
+	LicenseConcluded string `json:"licenseConcluded"`
+}
+
+// spdxDoc is a minimal SPDX 2.3 document: just enough for a compliance
+// pipeline to read concluded licenses back out per package.
+type spdxDoc struct {
+	SPDXVersion       string        `json:"spdxVersion"`
+	DataLicense       string        `json:"dataLicense"`
+	SPDXID            string        `json:"SPDXID"`
+	Name              string        `json:"name"`
+	DocumentNamespace string        `json:"documentNamespace"`
+	Packages          []spdxPackage `json:"packages"`
+}
+
+// spdxDocument renders entries as a minimal SPDX 2.3 document.
+func spdxDocument(entries []bomEntry) spdxDoc {
+	doc := spdxDoc{
+		SPDXVersion:       "SPDX-2.3",
+		DataLicense:       "CC0-1.0",
+		SPDXID:            "SPDXRef-DOCUMENT",
+		Name:              "license-notices-linter-bom",
+		DocumentNamespace: "https://spdx.org/spdxdocs/license-notices-linter",
+	}
+	for i, e := range entries {
+		license := "NOASSERTION"
+		if len(e.Licenses) > 0 {
+			license = e.Licenses[0].Type
+		}
+		doc.Packages = append(doc.Packages, spdxPackage{
+			SPDXID:           fmt.Sprintf("SPDXRef-Package-%d", i),
+			Name:             e.Project,
+			VersionInfo:      e.Version,
+			LicenseConcluded: license,
+		})
+	}
+	return doc
+}
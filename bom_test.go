@@ -0,0 +1,75 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeVendorTree lays out a minimal vendor/modules.txt plus, for each
+// module in withLicense, a vendored copy with an MIT LICENSE file.
+func writeVendorTree(t *testing.T, dir string, modules []moduleInfo, withLicense map[string]bool) {
+	t.Helper()
+	vendor := filepath.Join(dir, "vendor")
+	if err := os.MkdirAll(vendor, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	var txt string
+	for _, m := range modules {
+		txt += "# " + m.Path + " " + m.Version + "\n"
+		if withLicense[m.Path] {
+			modDir := filepath.Join(vendor, m.Path)
+			if err := os.MkdirAll(modDir, 0o755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(filepath.Join(modDir, "LICENSE"), []byte(rawLicenseTemplates["MIT"]), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := os.WriteFile(filepath.Join(vendor, "modules.txt"), []byte(txt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestBuildBOMSurfacesVersion(t *testing.T) {
+	dir := t.TempDir()
+	writeVendorTree(t, dir, []moduleInfo{{Path: "example.com/foo", Version: "v1.2.3"}}, map[string]bool{"example.com/foo": true})
+
+	entries, err := buildBOM(dir, bomOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("got %d entries, want 1", len(entries))
+	}
+	if entries[0].Version != "v1.2.3" {
+		t.Fatalf("Version = %q, want %q", entries[0].Version, "v1.2.3")
+	}
+	if len(entries[0].Licenses) != 1 || entries[0].Licenses[0].Type != "MIT" {
+		t.Fatalf("Licenses = %+v, want MIT", entries[0].Licenses)
+	}
+}
+
+func TestBuildBOMFailsClosedOnUnclassifiableLicense(t *testing.T) {
+	dir := t.TempDir()
+	writeVendorTree(t, dir, []moduleInfo{{Path: "example.com/nolicense", Version: "v0.0.1"}}, nil)
+
+	if _, err := buildBOM(dir, bomOptions{allow: map[string]bool{"MIT": true}}); err == nil {
+		t.Fatal("expected an error for an unclassifiable license under -allow, got nil")
+	}
+
+	// Without -allow, an unclassifiable license is reported but doesn't
+	// fail the build.
+	entries, err := buildBOM(dir, bomOptions{})
+	if err != nil {
+		t.Fatalf("buildBOM without -allow: %v", err)
+	}
+	if len(entries) != 1 || len(entries[0].Licenses) != 0 {
+		t.Fatalf("entries = %+v, want one entry with no licenses", entries)
+	}
+}
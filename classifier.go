@@ -0,0 +1,176 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	yaml "gopkg.in/yaml.v2"
+)
+
+// licenseMeta is the YAML front-matter of an embedded license template, in
+// the format used by the choosealicense.com / SPDX license-list-data
+// corpora: a "---" delimited block ahead of the license body.
+type licenseMeta struct {
+	Title    string `yaml:"title"`
+	Nickname string `yaml:"nickname"`
+}
+
+// licenseTemplate is a known SPDX license, identified by spdx and matched
+// against by its word-frequency vector.
+type licenseTemplate struct {
+	spdx   string
+	meta   licenseMeta
+	tokens map[string]int
+}
+
+var copyrightLineRE = regexp.MustCompile(`(?i)copyright (?:\(c\)|©)?\s*(?:\d{4}|\[year\]).*`)
+
+var wordRE = regexp.MustCompile(`[a-z0-9]+`)
+
+// normalizeLicenseText lowercases text, strips copyright lines (which vary
+// per project and would otherwise dominate the comparison), and collapses
+// whitespace, so that only the boilerplate license language is compared.
+func normalizeLicenseText(text string) string {
+	text = strings.ToLower(text)
+	text = copyrightLineRE.ReplaceAllString(text, "")
+	return strings.Join(strings.Fields(text), " ")
+}
+
+// tokenize turns normalized text into a word multiset.
+func tokenize(text string) map[string]int {
+	tokens := map[string]int{}
+	for _, w := range wordRE.FindAllString(text, -1) {
+		tokens[w]++
+	}
+	return tokens
+}
+
+// jaccard returns the Jaccard similarity of the word sets underlying a and
+// b: the size of their intersection over the size of their union.
+func jaccard(a, b map[string]int) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	inter := 0
+	for w := range a {
+		if _, ok := b[w]; ok {
+			inter++
+		}
+	}
+	union := len(a) + len(b) - inter
+	if union == 0 {
+		return 0
+	}
+	return float64(inter) / float64(union)
+}
+
+// parseLicenseTemplate splits a raw template (YAML front matter between
+// "---" lines, followed by the license body) and builds its token set.
+func parseLicenseTemplate(spdx, raw string) (*licenseTemplate, error) {
+	parts := strings.SplitN(raw, "---\n", 3)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("license template %q: malformed front matter", spdx)
+	}
+	var meta licenseMeta
+	if err := yaml.Unmarshal([]byte(parts[1]), &meta); err != nil {
+		return nil, fmt.Errorf("license template %q: %w", spdx, err)
+	}
+	return &licenseTemplate{
+		spdx:   spdx,
+		meta:   meta,
+		tokens: tokenize(normalizeLicenseText(parts[2])),
+	}, nil
+}
+
+// licenseTemplates is the embedded corpus of known SPDX licenses
+// classifyLicense matches against. It is intentionally a small, core set of permissive
+// licenses rather than the full SPDX list.
+var licenseTemplates []*licenseTemplate
+
+func init() {
+	for spdx, raw := range rawLicenseTemplates {
+		t, err := parseLicenseTemplate(spdx, raw)
+		if err != nil {
+			panic(err)
+		}
+		licenseTemplates = append(licenseTemplates, t)
+	}
+}
+
+// licenseMatch is the result of classifying a piece of text against the
+// embedded license corpus.
+type licenseMatch struct {
+	spdx  string
+	score float64
+}
+
+// classifyLicense compares text against every embedded license template and
+// returns the best match along with its confidence score.
+func classifyLicense(text string) licenseMatch {
+	tokens := tokenize(normalizeLicenseText(text))
+
+	var best licenseMatch
+	for _, t := range licenseTemplates {
+		if score := jaccard(tokens, t.tokens); score > best.score {
+			best = licenseMatch{spdx: t.spdx, score: score}
+		}
+	}
+	return best
+}
+
+// licenseFileNames are the conventional names under which a repository's
+// license text is found, in order of preference.
+var licenseFileNames = []string{"LICENSE", "LICENSE.txt", "LICENSE.md", "COPYING"}
+
+// findLicenseFile returns the path to dir's license file, if any.
+func findLicenseFile(dir string) (string, error) {
+	for _, name := range licenseFileNames {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("no license file found in %q", dir)
+}
+
+// classifyRepoLicense classifies dir's license file against the embedded
+// corpus.
+func classifyRepoLicense(dir string) (string, licenseMatch, error) {
+	path, err := findLicenseFile(dir)
+	if err != nil {
+		return "", licenseMatch{}, err
+	}
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", licenseMatch{}, err
+	}
+	return path, classifyLicense(string(b)), nil
+}
+
+// classifyFileLicense strips f's comment syntax from its full contents and
+// classifies the result, for source files that embed a license's full text
+// rather than just an SPDX tag.
+func classifyFileLicense(f file) (licenseMatch, error) {
+	b, err := ioutil.ReadFile(f.path)
+	if err != nil {
+		return licenseMatch{}, err
+	}
+
+	var body strings.Builder
+	for _, l := range splitLines(b) {
+		text, ok := f.spec.stripComment(trimEOL(l))
+		if !ok {
+			continue
+		}
+		body.WriteString(text)
+		body.WriteString(" ")
+	}
+	return classifyLicense(body.String()), nil
+}
@@ -0,0 +1,49 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestClassifyLicenseMatchesKnownLicense(t *testing.T) {
+	text := rawLicenseTemplates["MIT"]
+	match := classifyLicense(text)
+	if match.spdx != "MIT" {
+		t.Fatalf("spdx = %q, want MIT", match.spdx)
+	}
+	if match.score < 0.9 {
+		t.Fatalf("score = %v, want >= 0.9 for an exact match", match.score)
+	}
+}
+
+func TestClassifyLicenseIgnoresCopyrightLine(t *testing.T) {
+	withCopyright := "Copyright (c) 2024 Someone\n\n" + rawLicenseTemplates["MIT"]
+	withoutCopyright := classifyLicense(rawLicenseTemplates["MIT"])
+	got := classifyLicense(withCopyright)
+	if got.spdx != withoutCopyright.spdx {
+		t.Fatalf("spdx = %q, want %q", got.spdx, withoutCopyright.spdx)
+	}
+}
+
+func TestFindLicenseFile(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := findLicenseFile(dir); err == nil {
+		t.Fatal("expected an error when no license file is present")
+	}
+
+	path := filepath.Join(dir, "LICENSE")
+	if err := os.WriteFile(path, []byte("text"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	got, err := findLicenseFile(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != path {
+		t.Fatalf("got %q, want %q", got, path)
+	}
+}
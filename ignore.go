@@ -0,0 +1,100 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// licenseIgnoreFile is the name of the dedicated ignore file, in the same
+// one-glob-per-line syntax as the -ignore/-skip flags.
+const licenseIgnoreFile = ".licenseignore"
+
+// stringsFlag collects the values of a repeatable flag, e.g. -ignore
+// passed more than once.
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+// readLicenseIgnore reads dir's .licenseignore file, if any: one
+// doublestar glob per line, with blank lines and "#"-prefixed comments
+// ignored.
+func readLicenseIgnore(dir string) ([]string, error) {
+	f, err := os.Open(filepath.Join(dir, licenseIgnoreFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var patterns []string
+	sc := bufio.NewScanner(f)
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, line)
+	}
+	return patterns, sc.Err()
+}
+
+// validateGlobs checks that every pattern is a well-formed doublestar
+// glob, so that a typo surfaces as a clean error up front rather than as a
+// pattern that silently never matches (match discards doublestar's
+// pattern errors).
+func validateGlobs(patterns []string) error {
+	for _, p := range patterns {
+		if !doublestar.ValidatePattern(p) {
+			return fmt.Errorf("invalid glob pattern %q", p)
+		}
+	}
+	return nil
+}
+
+// globIgnorer skips paths matching any of a set of validated doublestar
+// globs, rooted at dir.
+type globIgnorer struct {
+	dir      string
+	patterns []string
+}
+
+// newGlobIgnorer validates patterns and builds a globIgnorer rooted at dir.
+func newGlobIgnorer(dir string, patterns []string) (*globIgnorer, error) {
+	if err := validateGlobs(patterns); err != nil {
+		return nil, err
+	}
+	return &globIgnorer{dir: dir, patterns: patterns}, nil
+}
+
+// match reports whether path (rooted at g.dir) matches any of g's
+// patterns.
+func (g *globIgnorer) match(path string) bool {
+	rel, err := filepath.Rel(g.dir, path)
+	if err != nil {
+		return false
+	}
+	rel = filepath.ToSlash(rel)
+	for _, p := range g.patterns {
+		if ok, _ := doublestar.Match(p, rel); ok {
+			return true
+		}
+	}
+	return false
+}
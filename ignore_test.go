@@ -0,0 +1,79 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadLicenseIgnore(t *testing.T) {
+	dir := t.TempDir()
+	content := "# a comment\n\n**/*.pb.go\nvendor/**\n"
+	if err := os.WriteFile(filepath.Join(dir, licenseIgnoreFile), []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := readLicenseIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"**/*.pb.go", "vendor/**"}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+func TestReadLicenseIgnoreMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	got, err := readLicenseIgnore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != nil {
+		t.Fatalf("got %v, want nil for a missing .licenseignore", got)
+	}
+}
+
+func TestValidateGlobsInvalidPattern(t *testing.T) {
+	if err := validateGlobs([]string{"[invalid"}); err == nil {
+		t.Fatal("expected an error for an invalid glob pattern")
+	}
+	if err := validateGlobs([]string{"**/*.go"}); err != nil {
+		t.Fatalf("unexpected error for a valid pattern: %v", err)
+	}
+}
+
+func TestNewGlobIgnorerRejectsInvalidPattern(t *testing.T) {
+	if _, err := newGlobIgnorer(".", []string{"[invalid"}); err == nil {
+		t.Fatal("expected newGlobIgnorer to reject an invalid pattern up front")
+	}
+}
+
+func TestGlobIgnorerMatch(t *testing.T) {
+	g, err := newGlobIgnorer("/repo", []string{"**/*.pb.go", "vendor/**"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{"/repo/api/thing.pb.go", true},
+		{"/repo/vendor/foo/bar.go", true},
+		{"/repo/main.go", false},
+	}
+	for _, tt := range tests {
+		if got := g.match(tt.path); got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
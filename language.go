@@ -0,0 +1,115 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+)
+
+// languageSpec describes how license headers are written in a given
+// programming language: either as a repeated line comment, or as a single
+// block comment for languages that have no line-comment syntax.
+type languageSpec struct {
+	lineComment string
+	blockOpen   string
+	blockClose  string
+}
+
+// token returns the marker used to represent this language's comment style
+// when reporting or printing a header, preferring the line-comment form.
+func (l languageSpec) token() string {
+	if l.lineComment != "" {
+		return l.lineComment
+	}
+	return l.blockOpen
+}
+
+// stripComment strips the comment syntax from line and returns the text
+// inside it. ok is false if line isn't a comment in this language.
+func (l languageSpec) stripComment(line string) (text string, ok bool) {
+	line = strings.TrimSpace(line)
+	if l.lineComment != "" && strings.HasPrefix(line, l.lineComment) {
+		return strings.TrimSpace(strings.TrimPrefix(line, l.lineComment)), true
+	}
+	if l.blockOpen != "" && strings.HasPrefix(line, l.blockOpen) {
+		line = strings.TrimPrefix(line, l.blockOpen)
+		line = strings.TrimSuffix(strings.TrimSpace(line), l.blockClose)
+		return strings.TrimSpace(line), true
+	}
+	return "", false
+}
+
+// languageSpecs maps go-enry language names to their comment syntax. This is
+// intentionally not exhaustive: parseFileBuf returns errUnknownLanguage for
+// anything not listed here.
+var languageSpecs = map[string]languageSpec{
+	"Go":          {lineComment: "//"},
+	"Python":      {lineComment: "#"},
+	"Ruby":        {lineComment: "#"},
+	"Shell":       {lineComment: "#"},
+	"Perl":        {lineComment: "#"},
+	"YAML":        {lineComment: "#"},
+	"TOML":        {lineComment: "#"},
+	"Dockerfile":  {lineComment: "#"},
+	"Makefile":    {lineComment: "#"},
+	"C":           {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"C++":         {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"C#":          {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"Java":        {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"JavaScript":  {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"TypeScript":  {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"Rust":        {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"Swift":       {lineComment: "//", blockOpen: "/*", blockClose: "*/"},
+	"CSS":         {blockOpen: "/*", blockClose: "*/"},
+	"HTML":        {blockOpen: "<!--", blockClose: "-->"},
+	"XML":         {blockOpen: "<!--", blockClose: "-->"},
+	"SQL":         {lineComment: "--"},
+	"Lua":         {lineComment: "--"},
+	"Common Lisp": {lineComment: ";"},
+}
+
+// interpreterLanguageMap maps shebang interpreter names (as found after
+// "#!" or after "#!/usr/bin/env") to go-enry language names, for files
+// whose extension doesn't otherwise identify their language.
+var interpreterLanguageMap = map[string]string{
+	"sh":      "Shell",
+	"bash":    "Shell",
+	"zsh":     "Shell",
+	"dash":    "Shell",
+	"python":  "Python",
+	"python2": "Python",
+	"python3": "Python",
+	"ruby":    "Ruby",
+	"perl":    "Perl",
+	"node":    "JavaScript",
+	"nodejs":  "JavaScript",
+}
+
+// languageFromShebang inspects the first line of b for a "#!" interpreter
+// directive and returns the language it implies, if any.
+func languageFromShebang(b []byte) (string, bool) {
+	if nl := bytes.IndexByte(b, '\n'); nl >= 0 {
+		b = b[:nl]
+	}
+	line := bytes.TrimSpace(b)
+	if !bytes.HasPrefix(line, []byte("#!")) {
+		return "", false
+	}
+
+	fields := strings.Fields(string(line[2:]))
+	if len(fields) == 0 {
+		return "", false
+	}
+
+	interp := filepath.Base(fields[0])
+	if interp == "env" && len(fields) > 1 {
+		interp = filepath.Base(fields[1])
+	}
+	interp = strings.TrimRight(interp, "0123456789.")
+
+	lang, found := interpreterLanguageMap[interp]
+	return lang, found
+}
@@ -12,121 +12,51 @@ import (
 	"io/ioutil"
 	"log"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 
 	"github.com/go-enry/go-enry/v2"
-	ignore "github.com/sabhiram/go-gitignore"
 )
 
 var (
 	update  = flag.Bool("w", false, "Update files in place")
 	verbose = flag.Bool("v", false, "Verbose")
-)
+	detect  = flag.Bool("detect", false, "Classify license text against a known corpus when no SPDX tag is found")
 
-var (
-	commentPrefixMap = map[string]string{
-		"Go": "//",
-	}
-	errUnknownLanguage = fmt.Errorf("unknown language")
-)
+	bom      = flag.Bool("bom", false, "Generate a bill-of-materials for vendored/module dependencies instead of linting")
+	bomSPDX  = flag.Bool("spdx", false, "With -bom, emit an SPDX 2.3 document instead of the native JSON shape")
+	bomAllow = flag.String("allow", "", "With -bom, comma-separated SPDX IDs dependencies are allowed to use")
+	bomDeny  = flag.String("deny", "", "With -bom, comma-separated SPDX IDs dependencies are forbidden from using")
 
-var (
-	ignorePreds = []func(string) bool{
-		enry.IsConfiguration,
-		enry.IsDocumentation,
-		enry.IsDotFile,
-		enry.IsImage,
-		enry.IsVendor,
-		func(path string) bool {
-			b, err := ioutil.ReadFile(path)
-			if err != nil {
-				panic(err)
-			}
-			return enry.IsBinary(b)
-		},
-	}
-)
-
-func commentPrefix(filename string) (string, error) {
-	b, err := ioutil.ReadFile(filename)
-	if err != nil {
-		return "", err
-	}
-	lang := enry.GetLanguage(filename, b)
+	authors    = flag.Bool("authors", false, "With -w, infer each file's copyright holders from git history instead of using one canonical line")
+	headerTmpl = flag.String("template", "", "With -authors, path to a Go text/template file rendering the copyright line (default: built-in)")
 
-	p, found := commentPrefixMap[lang]
-	if !found {
-		return "", fmt.Errorf("%w %q for %q", errUnknownLanguage, lang, filename)
-	}
-	return p, nil
-}
+	ignoreGlobs stringsFlag
+)
 
-func crawlFiles(dir string) (res []string, err error) {
-	err = filepath.Walk(dir,
-		func(path string, info os.FileInfo, err error) error {
-			if err != nil {
-				return err
-			}
-			if filepath.Base(path) == ".git" {
-				return filepath.SkipDir
-			}
-			if !info.IsDir() {
-				res = append(res, path)
-			}
-			return nil
-		})
-	return res, err
+func init() {
+	flag.Var(&ignoreGlobs, "ignore", "Doublestar glob (e.g. \"**/*.pb.go\") of files to skip; repeatable")
+	flag.Var(&ignoreGlobs, "skip", "Alias for -ignore")
 }
 
-func ignoreFile(path string, preds ...func(string) bool) bool {
-	for _, f := range preds {
-		if f(path) {
-			return true
-		}
-	}
-	return false
+var errUnknownLanguage = fmt.Errorf("unknown language")
+
+// ignorePreds are predicates run against each file's path and content
+// (read once by the crawl pipeline, not re-read per predicate).
+var ignorePreds = []func(string, []byte) bool{
+	func(path string, _ []byte) bool { return enry.IsConfiguration(path) },
+	func(path string, _ []byte) bool { return enry.IsDocumentation(path) },
+	func(path string, _ []byte) bool { return enry.IsDotFile(path) },
+	func(path string, _ []byte) bool { return enry.IsImage(path) },
+	func(path string, _ []byte) bool { return enry.IsVendor(path) },
+	func(_ string, b []byte) bool { return enry.IsBinary(b) },
 }
 
 type file struct {
-	path          string
-	commentPrefix string
-	copyright     string
-	license       string
-}
-
-func parseFile(path string) (file, error) {
-	pfx, err := commentPrefix(path)
-	if err != nil {
-		return file{}, err
-	}
-	f, err := os.Open(path)
-	if err != nil {
-		return file{}, err
-	}
-	defer f.Close()
-
-	lines, err := head(f, 5)
-	if err != nil {
-		return file{}, err
-	}
-
-	res := file{
-		path:          path,
-		commentPrefix: pfx,
-	}
-
-	for _, l := range lines {
-		if strings.HasPrefix(l, fmt.Sprintf("%s Copyright ", pfx)) {
-			res.copyright = l[len(pfx)+1:]
-		}
-		if strings.HasPrefix(l, fmt.Sprintf("%s SPDX-License-Identifier: ", pfx)) {
-			res.license = l[len(pfx)+1:]
-		}
-	}
-
-	return res, nil
+	path      string
+	spec      languageSpec
+	copyright string
+	license   string
 }
 
 // head returns up to the first n lines of a reader.
@@ -142,6 +72,30 @@ func head(r io.Reader, n int) (res []string, err error) {
 	return res, err
 }
 
+// spdxIDSet turns a list of SPDX IDs (as accumulated from -allow/-deny) into
+// a set, ignoring empty entries.
+func spdxIDSet(ids []string) map[string]bool {
+	set := map[string]bool{}
+	for _, id := range ids {
+		if id = strings.TrimSpace(id); id != "" {
+			set[id] = true
+		}
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	return set
+}
+
+// splitCSV splits a comma-separated flag value, returning nil for an empty
+// string rather than a slice containing one empty element.
+func splitCSV(csv string) []string {
+	if csv == "" {
+		return nil
+	}
+	return strings.Split(csv, ",")
+}
+
 func mainE() error {
 	flag.Parse()
 
@@ -149,43 +103,67 @@ func mainE() error {
 	if flag.NArg() > 0 {
 		dir = flag.Arg(0)
 	}
-	return run(dir, *update, *verbose)
+
+	tmplText := defaultHeaderTemplate
+	if *headerTmpl != "" {
+		b, err := ioutil.ReadFile(*headerTmpl)
+		if err != nil {
+			return err
+		}
+		tmplText = string(b)
+	}
+
+	return Run(Options{
+		Dir: dir,
+
+		Update:  *update,
+		Verbose: *verbose,
+		Detect:  *detect,
+
+		Authors:        *authors,
+		HeaderTemplate: tmplText,
+
+		IgnoreGlobs: ignoreGlobs,
+
+		BOM:      *bom,
+		BOMSPDX:  *bomSPDX,
+		BOMAllow: splitCSV(*bomAllow),
+		BOMDeny:  splitCSV(*bomDeny),
+	})
 }
 
-func run(dir string, update, verbose bool) error {
-	gi, err := ignore.CompileIgnoreFile(filepath.Join(dir, ".gitignore"))
-	if err != nil {
-		return err
+// Run lints (or, with opts.Update, rewrites) the files under opts.Dir.
+func Run(opts Options) error {
+	if opts.BOM {
+		bomOpts := bomOptions{allow: spdxIDSet(opts.BOMAllow), deny: spdxIDSet(opts.BOMDeny)}
+		return runBOM(opts.Dir, bomOpts, opts.BOMSPDX)
 	}
 
-	gimatch := func(path string) bool {
-		r, err := filepath.Rel(dir, path)
-		if err != nil {
-			panic(err)
-		}
-		return gi.MatchesPath(r)
+	dir := opts.Dir
+	update := opts.Update
+	verbose := opts.Verbose
+	detect := opts.Detect
+	authors := opts.Authors
+	headerTemplate := opts.HeaderTemplate
+	if headerTemplate == "" {
+		headerTemplate = defaultHeaderTemplate
 	}
-	preds := append(ignorePreds, gimatch)
 
-	var candidates []file
+	ignorePatterns, err := readLicenseIgnore(dir)
+	if err != nil {
+		return err
+	}
+	ignorePatterns = append(ignorePatterns, opts.IgnoreGlobs...)
 
-	allFiles, err := crawlFiles(dir)
+	globs, err := newGlobIgnorer(dir, ignorePatterns)
 	if err != nil {
 		return err
 	}
-	for _, path := range allFiles {
-		if ignoreFile(path, preds...) {
-			continue
-		}
+	preds := append(ignorePreds, func(path string, _ []byte) bool { return globs.match(path) })
 
-		f, err := parseFile(path)
-		if errors.Is(err, errUnknownLanguage) {
-			continue
-		}
-		if err != nil {
-			return err
-		}
-		candidates = append(candidates, f)
+	candidates, err := crawlAndParse(dir, preds)
+	if err != nil {
+		return err
 	}
 
 	copyrights := map[string]int{}
@@ -201,8 +179,31 @@ func run(dir string, update, verbose bool) error {
 	if len(copyrights) == 0 {
 		return fmt.Errorf("cannot find any copyright notice in any source file")
 	}
+
+	if detect {
+		for _, f := range candidates {
+			if f.license != "" {
+				continue
+			}
+			if m, err := classifyFileLicense(f); err == nil && m.spdx != "" {
+				fmt.Fprintf(os.Stderr, "-detect: %s: best match %s (%.0f%% confidence)\n", f.path, m.spdx, m.score*100)
+			}
+		}
+	}
+
 	if len(licenses) == 0 {
-		return fmt.Errorf("cannot find any SPDX-License-Identifier tag in any source file")
+		if !detect {
+			return fmt.Errorf("cannot find any SPDX-License-Identifier tag in any source file")
+		}
+		path, m, err := classifyRepoLicense(dir)
+		if err != nil {
+			return fmt.Errorf("cannot find any SPDX-License-Identifier tag in any source file, and license detection failed: %w", err)
+		}
+		if m.spdx == "" {
+			return fmt.Errorf("cannot find any SPDX-License-Identifier tag in any source file, and could not classify %q", path)
+		}
+		fmt.Fprintf(os.Stderr, "-detect: %s: best match %s (%.0f%% confidence), using it as the canonical license\n", path, m.spdx, m.score*100)
+		licenses["SPDX-License-Identifier: "+m.spdx] = 1
 	}
 
 	top := func(m map[string]int) string { return sortMapDesc(m)[0] }
@@ -211,6 +212,7 @@ func run(dir string, update, verbose bool) error {
 	license := top(licenses)
 
 	commentPrefixes := map[string]int{}
+	specsByToken := map[string]languageSpec{}
 	for _, f := range candidates {
 		toUpdate := false
 
@@ -219,12 +221,13 @@ func run(dir string, update, verbose bool) error {
 			if verbose {
 				fmt.Fprintf(os.Stderr, "file %q %s\n", f.path, fmt.Sprintf(about, args...))
 			}
-			commentPrefixes[f.commentPrefix]++
+			commentPrefixes[f.spec.token()]++
+			specsByToken[f.spec.token()] = f.spec
 		}
 
 		if f.copyright == "" {
 			complain("is missing the copyright notice")
-		} else if want, got := copyright, f.copyright; want != got {
+		} else if want, got := copyright, f.copyright; !authors && want != got {
 			complain("has minority copyright notice: want: %q, got: %q", want, got)
 		}
 		if f.license == "" {
@@ -235,6 +238,26 @@ func run(dir string, update, verbose bool) error {
 
 		if toUpdate {
 			fmt.Fprintf(os.Stderr, " M %s\n", f.path)
+			if update {
+				fileCopyright := copyright
+				if authors {
+					a, err := inferAuthorship(dir, f.path)
+					if err != nil {
+						return fmt.Errorf("inferring authorship for %q: %w", f.path, err)
+					}
+					if fileCopyright, err = renderCopyright(headerTemplate, a); err != nil {
+						return err
+					}
+				}
+
+				diff, err := rewriteFile(f.path, f.spec, fileCopyright, license)
+				if err != nil {
+					return fmt.Errorf("rewriting %q: %w", f.path, err)
+				}
+				if diff != "" && !verbose {
+					fmt.Print(diff)
+				}
+			}
 		}
 	}
 
@@ -242,11 +265,11 @@ func run(dir string, update, verbose bool) error {
 		if len(commentPrefixes) > 0 {
 			fmt.Fprintf(os.Stderr, "\n^^^ These files should contain these comments at the top:\n")
 			pfx := top(commentPrefixes)
-			fmt.Printf("%s %s\n", pfx, copyright)
-			fmt.Printf("%s %s\n", pfx, license)
+			for _, line := range buildHeader(specsByToken[pfx], copyright, license) {
+				fmt.Println(line)
+			}
 			fmt.Println()
 		}
-		return nil
 	}
 	return nil
 }
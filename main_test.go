@@ -4,7 +4,11 @@
 package main
 
 import (
+	"io"
+	"os"
+	"path/filepath"
 	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -21,4 +25,89 @@ func TestSortMapDesc(t *testing.T) {
 	if got, want := s, []string{"bar", "foo", "quz", "baz"}; !reflect.DeepEqual(got, want) {
 		t.Fatalf("got: %q, want: %q", got, want)
 	}
-}
\ No newline at end of file
+}
+
+// captureStdout runs fn with os.Stdout redirected to a pipe and returns
+// whatever it wrote.
+func captureStdout(t *testing.T, fn func()) string {
+	t.Helper()
+	orig := os.Stdout
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Stdout = w
+	defer func() { os.Stdout = orig }()
+
+	fn()
+
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+// TestRunReportUsesClosedBlockComment ensures the non-update report for a
+// block-comment-only language (e.g. CSS) prints a properly closed comment,
+// not just the opening delimiter.
+func TestRunReportUsesClosedBlockComment(t *testing.T) {
+	dir := t.TempDir()
+	const header = "/* Copyright 2020 VMware, Inc. */\n/* SPDX-License-Identifier: BSD-2-Clause */\n\n"
+	if err := os.WriteFile(filepath.Join(dir, "licensed.css"), []byte(header+"body { color: blue; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "styles.css"), []byte("body { color: red; }\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := captureStdout(t, func() {
+		if err := Run(Options{Dir: dir}); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	})
+
+	if !strings.Contains(out, "*/") {
+		t.Fatalf("report doesn't close the block comment: %q", out)
+	}
+}
+
+// TestRunDetectFallbackUsesCanonicalLicenseFormat ensures that when -detect
+// falls back to classifying the repo's LICENSE file, the inferred license is
+// stored (and written) in the canonical "SPDX-License-Identifier: X" form,
+// not the bare SPDX id, so a second run converges instead of re-flagging
+// every file it just wrote.
+func TestRunDetectFallbackUsesCanonicalLicenseFormat(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "LICENSE"), []byte(rawLicenseTemplates["MIT"]), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "main.go")
+	if err := os.WriteFile(path, []byte("// Copyright 2020 VMware, Inc.\n\npackage main\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Run(Options{Dir: dir, Update: true, Detect: true}); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	got, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(got), "// SPDX-License-Identifier: MIT") {
+		t.Fatalf("file doesn't contain the canonical SPDX line: %q", got)
+	}
+
+	// A second run should find the file already compliant and not flag it
+	// as missing the license identifier again.
+	out := captureStdout(t, func() {
+		if err := Run(Options{Dir: dir, Detect: true}); err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+	})
+	if out != "" {
+		t.Fatalf("second run should report nothing to fix, got: %q", out)
+	}
+}
@@ -0,0 +1,29 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+// Options configures a linting (or, with Update, rewriting) run. It mirrors
+// the command-line flags, so the tool can be embedded as a library and
+// invoked directly instead of only via main.
+type Options struct {
+	Dir string
+
+	Update  bool
+	Verbose bool
+	Detect  bool
+
+	Authors bool
+	// HeaderTemplate is the Go text/template used to render an inferred
+	// copyright line when Authors is set. Empty means defaultHeaderTemplate.
+	HeaderTemplate string
+
+	// IgnoreGlobs are doublestar glob patterns (e.g. "**/*.pb.go" or
+	// "vendor/**") for files to skip, in addition to .licenseignore.
+	IgnoreGlobs []string
+
+	BOM      bool
+	BOMSPDX  bool
+	BOMAllow []string
+	BOMDeny  []string
+}
@@ -0,0 +1,187 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/go-enry/go-enry/v2"
+)
+
+// crawlFiles walks dir and returns every non-directory path under it,
+// skipping .git directories.
+func crawlFiles(dir string) (res []string, err error) {
+	err = filepath.Walk(dir,
+		func(path string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if filepath.Base(path) == ".git" {
+				return filepath.SkipDir
+			}
+			if !info.IsDir() {
+				res = append(res, path)
+			}
+			return nil
+		})
+	return res, err
+}
+
+// parseFileBuf detects b's language and parses its header lines, given b
+// is already the full contents of the file at path. Unlike the old
+// commentPrefix+parseFile pair, this reads the file exactly once.
+func parseFileBuf(path string, b []byte) (file, error) {
+	lang := enry.GetLanguage(path, b)
+
+	spec, found := languageSpecs[lang]
+	if !found {
+		if shebangLang, ok := languageFromShebang(b); ok {
+			if spec, found = languageSpecs[shebangLang]; found {
+				lang = shebangLang
+			}
+		}
+	}
+	if !found {
+		return file{}, fmt.Errorf("%w %q for %q", errUnknownLanguage, lang, path)
+	}
+
+	lines, err := head(bytes.NewReader(b), 5)
+	if err != nil {
+		return file{}, err
+	}
+
+	res := file{path: path, spec: spec}
+	for _, l := range lines {
+		text, ok := spec.stripComment(l)
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(text, "Copyright ") {
+			res.copyright = text
+		}
+		if strings.HasPrefix(text, "SPDX-License-Identifier: ") {
+			res.license = text
+		}
+	}
+	return res, nil
+}
+
+// processFile reads path exactly once and runs preds, language detection
+// and header parsing against that single buffer. skip is true if the file
+// should be dropped without error: it matched an ignore predicate, or its
+// language isn't one the linter understands.
+func processFile(path string, preds []func(string, []byte) bool) (f file, skip bool, err error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return file{}, false, err
+	}
+	for _, pred := range preds {
+		if pred(path, b) {
+			return file{}, true, nil
+		}
+	}
+
+	f, err = parseFileBuf(path, b)
+	if errors.Is(err, errUnknownLanguage) {
+		return file{}, true, nil
+	}
+	if err != nil {
+		return file{}, false, err
+	}
+	return f, false, nil
+}
+
+// crawlAndParse lists every path under dir with crawlFiles, then processes
+// them concurrently: one goroutine emits the listed paths on a channel, and
+// a pool of runtime.NumCPU() workers each read a file, apply preds, and
+// parse its header, all against the same read. The walk itself finishes
+// before any file is processed; it does not overlap with the workers. The
+// first worker error cancels the remaining work; results are sorted by
+// path before being returned so diagnostics are deterministic.
+func crawlAndParse(dir string, preds []func(string, []byte) bool) ([]file, error) {
+	paths, err := crawlFiles(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	pathCh := make(chan string)
+	go func() {
+		defer close(pathCh)
+		for _, p := range paths {
+			select {
+			case pathCh <- p:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	type result struct {
+		file file
+		err  error
+	}
+	resCh := make(chan result)
+
+	workers := runtime.NumCPU()
+	if workers < 1 {
+		workers = 1
+	}
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for path := range pathCh {
+				f, skip, err := processFile(path, preds)
+				if skip {
+					continue
+				}
+				select {
+				case resCh <- result{file: f, err: err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(resCh)
+	}()
+
+	var candidates []file
+	var firstErr error
+	for r := range resCh {
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+				cancel()
+			}
+			continue
+		}
+		candidates = append(candidates, r.file)
+	}
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].path < candidates[j].path })
+	return candidates, nil
+}
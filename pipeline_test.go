@@ -0,0 +1,66 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCrawlAndParse(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"main.go":       "// Copyright 2020 VMware, Inc.\n// SPDX-License-Identifier: BSD-2-Clause\n\npackage main\n",
+		"vendor/dep.go": "package dep\n",
+		"README.md":     "# hello\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	candidates, err := crawlAndParse(dir, ignorePreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, f := range candidates {
+		rel, err := filepath.Rel(dir, f.path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, rel)
+	}
+	if len(got) != 1 || got[0] != "main.go" {
+		t.Fatalf("candidates = %v, want [main.go] (vendor/ and README.md should be skipped)", got)
+	}
+	if candidates[0].copyright != "Copyright 2020 VMware, Inc." {
+		t.Fatalf("copyright = %q", candidates[0].copyright)
+	}
+	if candidates[0].license != "SPDX-License-Identifier: BSD-2-Clause" {
+		t.Fatalf("license = %q", candidates[0].license)
+	}
+}
+
+func TestCrawlAndParseUnknownLanguageSkipped(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "data.xyz123"), []byte("???"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	candidates, err := crawlAndParse(dir, ignorePreds)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(candidates) != 0 {
+		t.Fatalf("candidates = %v, want none", candidates)
+	}
+}
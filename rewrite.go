@@ -0,0 +1,228 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// buildHeader renders the canonical copyright and license lines in the
+// comment syntax of spec.
+func buildHeader(spec languageSpec, copyright, license string) []string {
+	if spec.lineComment != "" {
+		return []string{
+			spec.lineComment + " " + copyright,
+			spec.lineComment + " " + license,
+		}
+	}
+	return []string{
+		spec.blockOpen + " " + copyright + " " + spec.blockClose,
+		spec.blockOpen + " " + license + " " + spec.blockClose,
+	}
+}
+
+// splitLines splits b into lines, each retaining its trailing line ending
+// (if any), so that the original line endings survive a round trip.
+func splitLines(b []byte) []string {
+	if len(b) == 0 {
+		return nil
+	}
+	var lines []string
+	start := 0
+	for i := 0; i < len(b); i++ {
+		if b[i] == '\n' {
+			lines = append(lines, string(b[start:i+1]))
+			start = i + 1
+		}
+	}
+	if start < len(b) {
+		lines = append(lines, string(b[start:]))
+	}
+	return lines
+}
+
+// trimEOL strips a trailing "\r\n" or "\n" from a line produced by
+// splitLines.
+func trimEOL(line string) string {
+	return strings.TrimRight(line, "\r\n")
+}
+
+// preambleLen returns the number of leading lines that must stay ahead of
+// the license header: a shebang line, followed by any Go build constraint
+// lines (and the blank line that must separate them from the package
+// clause).
+func preambleLen(lines []string) int {
+	i := 0
+	if i < len(lines) && strings.HasPrefix(trimEOL(lines[i]), "#!") {
+		i++
+	}
+	for i < len(lines) {
+		l := strings.TrimSpace(trimEOL(lines[i]))
+		if strings.HasPrefix(l, "//go:build") || strings.HasPrefix(l, "// +build") {
+			i++
+			continue
+		}
+		break
+	}
+	if i > 0 && i < len(lines) && trimEOL(lines[i]) == "" {
+		i++
+	}
+	return i
+}
+
+// rewriteLines returns lines with the canonical copyright and license
+// header applied: existing copyright/license comment lines (within the
+// leading lines parseFileBuf inspects) are replaced in place, or the header is
+// inserted after the preamble if neither is already present.
+func rewriteLines(lines []string, spec languageSpec, copyright, license string) []string {
+	header := buildHeader(spec, copyright, license)
+	eol := "\n"
+	if len(lines) > 0 && strings.HasSuffix(lines[0], "\r\n") {
+		eol = "\r\n"
+	}
+
+	const scanLines = 5
+	limit := scanLines
+	if limit > len(lines) {
+		limit = len(lines)
+	}
+
+	copyrightIdx, licenseIdx := -1, -1
+	for i := 0; i < limit; i++ {
+		text, ok := spec.stripComment(trimEOL(lines[i]))
+		if !ok {
+			continue
+		}
+		if strings.HasPrefix(text, "Copyright ") && copyrightIdx == -1 {
+			copyrightIdx = i
+		}
+		if strings.HasPrefix(text, "SPDX-License-Identifier: ") && licenseIdx == -1 {
+			licenseIdx = i
+		}
+	}
+
+	// The copyright and license lines are independent: a file can be
+	// missing either one (or both), and each needs to be inserted or
+	// replaced in place on its own, rather than only inserting both when
+	// neither is present.
+	res := make([]string, len(lines))
+	copy(res, lines)
+
+	switch {
+	case copyrightIdx != -1 && licenseIdx != -1:
+		res[copyrightIdx] = header[0] + eol
+		res[licenseIdx] = header[1] + eol
+	case copyrightIdx != -1:
+		// License is missing: keep the copyright line, insert the license
+		// line right after it.
+		res[copyrightIdx] = header[0] + eol
+		res = insertLine(res, copyrightIdx+1, header[1]+eol)
+	case licenseIdx != -1:
+		// Copyright is missing: keep the license line, insert the
+		// copyright line right before it.
+		res[licenseIdx] = header[1] + eol
+		res = insertLine(res, licenseIdx, header[0]+eol)
+	default:
+		// Neither is present: prepend both after the preamble.
+		at := preambleLen(lines)
+		out := make([]string, 0, len(res)+3)
+		out = append(out, res[:at]...)
+		out = append(out, header[0]+eol, header[1]+eol, ""+eol)
+		out = append(out, res[at:]...)
+		res = out
+	}
+
+	return res
+}
+
+// insertLine returns lines with l inserted at index at.
+func insertLine(lines []string, at int, l string) []string {
+	res := make([]string, 0, len(lines)+1)
+	res = append(res, lines[:at]...)
+	res = append(res, l)
+	res = append(res, lines[at:]...)
+	return res
+}
+
+// unifiedDiff renders a minimal unified diff between before and after,
+// which are always small (a handful of header lines), so a full LCS diff
+// isn't needed: everything before the first changed line and after the
+// last changed line is identical, and only the lines in between differ.
+func unifiedDiff(path string, before, after []string) string {
+	start := 0
+	for start < len(before) && start < len(after) && before[start] == after[start] {
+		start++
+	}
+	endBefore, endAfter := len(before), len(after)
+	for endBefore > start && endAfter > start && before[endBefore-1] == after[endAfter-1] {
+		endBefore--
+		endAfter--
+	}
+	if start == endBefore && start == endAfter {
+		return ""
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- a/%s\n", path)
+	fmt.Fprintf(&b, "+++ b/%s\n", path)
+	fmt.Fprintf(&b, "@@ -%d,%d +%d,%d @@\n", start+1, endBefore-start, start+1, endAfter-start)
+	for _, l := range before[start:endBefore] {
+		fmt.Fprintf(&b, "-%s\n", trimEOL(l))
+	}
+	for _, l := range after[start:endAfter] {
+		fmt.Fprintf(&b, "+%s\n", trimEOL(l))
+	}
+	return b.String()
+}
+
+// rewriteFile applies the canonical copyright and license header to path,
+// writing the result atomically (temp file + rename) if it differs from
+// the file's current contents. It returns the unified diff of the change,
+// or "" if the file was already up to date.
+func rewriteFile(path string, spec languageSpec, copyright, license string) (string, error) {
+	orig, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	before := splitLines(orig)
+	after := rewriteLines(before, spec, copyright, license)
+
+	diff := unifiedDiff(path, before, after)
+	if diff == "" {
+		return "", nil
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, l := range after {
+		if _, err := tmp.WriteString(l); err != nil {
+			tmp.Close()
+			return "", err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(tmp.Name(), info.Mode()); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return "", err
+	}
+
+	return diff, nil
+}
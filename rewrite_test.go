@@ -0,0 +1,47 @@
+// Copyright 2020 VMware, Inc.
+// SPDX-License-Identifier: BSD-2-Clause
+
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRewriteLines(t *testing.T) {
+	goSpec := languageSpec{lineComment: "//"}
+	const copyright = "Copyright 2020 VMware, Inc."
+	const license = "SPDX-License-Identifier: BSD-2-Clause"
+	const want = "// Copyright 2020 VMware, Inc.\n// SPDX-License-Identifier: BSD-2-Clause\n\npackage main\n"
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{
+			name:  "missing both copyright and license",
+			input: "package main\n",
+		},
+		{
+			name:  "missing license only",
+			input: "// Copyright 2019 Someone Else\n\npackage main\n",
+		},
+		{
+			name:  "missing copyright only",
+			input: "// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+		{
+			name:  "both present but wrong",
+			input: "// Copyright 2019 Someone Else\n// SPDX-License-Identifier: MIT\n\npackage main\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(rewriteLines(splitLines([]byte(tt.input)), goSpec, copyright, license), "")
+			if got != want {
+				t.Fatalf("rewriteLines(%q) = %q, want %q", tt.input, got, want)
+			}
+		})
+	}
+}